@@ -0,0 +1,73 @@
+/*
+Copyright 2015 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/url"
+	"time"
+)
+
+// LatencyMetricWithExemplar is implemented by LatencyMetric backends (for
+// example a Prometheus histogram) that can attach a trace/span ID as an
+// exemplar, so a slow-latency bucket in Grafana can link straight to the
+// corresponding trace. traceID and spanID are opaque strings; this package
+// takes no dependency on any particular tracing library, so callers are
+// responsible for extracting them (for example from
+// go.opentelemetry.io/otel/trace) and passing them in.
+type LatencyMetricWithExemplar interface {
+	LatencyMetric
+	ObserveWithExemplar(verb string, u url.URL, latency time.Duration, traceID, spanID string)
+}
+
+// ResultMetricWithExemplar is implemented by ResultMetric backends that can
+// attach a trace/span ID as an exemplar. See LatencyMetricWithExemplar for
+// the traceID/spanID contract.
+type ResultMetricWithExemplar interface {
+	ResultMetric
+	IncrementWithExemplar(code, method, host string, traceID, spanID string)
+}
+
+// No REST client call site extracts a trace/span ID and calls
+// ObserveLatency/IncrementResult yet (see the package doc comment's scope
+// note). That wiring would pull the active span out of the request context
+// via a tracing library such as go.opentelemetry.io/otel/trace in
+// Request.Do; it is deliberately kept out of this package so tools/metrics
+// itself gains no tracing dependency.
+
+// ObserveLatency records latency against metric. If metric implements
+// LatencyMetricWithExemplar and traceID is non-empty, the trace/span ID is
+// attached as an exemplar; otherwise this is equivalent to calling
+// metric.Observe directly.
+func ObserveLatency(metric LatencyMetric, verb string, u url.URL, latency time.Duration, traceID, spanID string) {
+	if withExemplar, ok := metric.(LatencyMetricWithExemplar); ok && traceID != "" {
+		withExemplar.ObserveWithExemplar(verb, u, latency, traceID, spanID)
+		return
+	}
+	metric.Observe(verb, u, latency)
+}
+
+// IncrementResult records a response code against metric. If metric
+// implements ResultMetricWithExemplar and traceID is non-empty, the
+// trace/span ID is attached as an exemplar; otherwise this is equivalent to
+// calling metric.Increment directly.
+func IncrementResult(metric ResultMetric, code, method, host, traceID, spanID string) {
+	if withExemplar, ok := metric.(ResultMetricWithExemplar); ok && traceID != "" {
+		withExemplar.IncrementWithExemplar(code, method, host, traceID, spanID)
+		return
+	}
+	metric.Increment(code, method, host)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2015 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+type fakeLatencyWithExemplar struct {
+	plainCalls    int
+	exemplarCalls int
+	lastTraceID   string
+	lastSpanID    string
+}
+
+func (f *fakeLatencyWithExemplar) Observe(string, url.URL, time.Duration) {
+	f.plainCalls++
+}
+
+func (f *fakeLatencyWithExemplar) ObserveWithExemplar(verb string, u url.URL, latency time.Duration, traceID, spanID string) {
+	f.exemplarCalls++
+	f.lastTraceID = traceID
+	f.lastSpanID = spanID
+}
+
+type fakeResultWithExemplar struct {
+	plainCalls    int
+	exemplarCalls int
+}
+
+func (f *fakeResultWithExemplar) Increment(string, string, string) {
+	f.plainCalls++
+}
+
+func (f *fakeResultWithExemplar) IncrementWithExemplar(code, method, host, traceID, spanID string) {
+	f.exemplarCalls++
+}
+
+func TestObserveLatencyWithValidTrace(t *testing.T) {
+	metric := &fakeLatencyWithExemplar{}
+	ObserveLatency(metric, "GET", url.URL{}, time.Second, "trace-1", "span-1")
+
+	if metric.exemplarCalls != 1 || metric.plainCalls != 0 {
+		t.Errorf("expected a single exemplar call, got exemplar=%d plain=%d", metric.exemplarCalls, metric.plainCalls)
+	}
+	if metric.lastTraceID != "trace-1" || metric.lastSpanID != "span-1" {
+		t.Errorf("exemplar recorded wrong ids: trace=%q span=%q", metric.lastTraceID, metric.lastSpanID)
+	}
+}
+
+func TestObserveLatencyWithNoTrace(t *testing.T) {
+	metric := &fakeLatencyWithExemplar{}
+	ObserveLatency(metric, "GET", url.URL{}, time.Second, "", "")
+
+	if metric.plainCalls != 1 || metric.exemplarCalls != 0 {
+		t.Errorf("expected a single plain call when traceID is empty, got exemplar=%d plain=%d", metric.exemplarCalls, metric.plainCalls)
+	}
+}
+
+func TestObserveLatencyWithoutExemplarSupport(t *testing.T) {
+	metric := noopLatency{}
+
+	// Must not panic even though noopLatency doesn't implement
+	// LatencyMetricWithExemplar.
+	ObserveLatency(metric, "GET", url.URL{}, time.Second, "trace-1", "span-1")
+}
+
+func TestIncrementResultWithValidTrace(t *testing.T) {
+	metric := &fakeResultWithExemplar{}
+	IncrementResult(metric, "200", "GET", "example.com", "trace-1", "span-1")
+
+	if metric.exemplarCalls != 1 || metric.plainCalls != 0 {
+		t.Errorf("expected a single exemplar call, got exemplar=%d plain=%d", metric.exemplarCalls, metric.plainCalls)
+	}
+}
+
+func TestIncrementResultWithoutExemplarSupport(t *testing.T) {
+	metric := noopResult{}
+
+	// Must not panic even though noopResult doesn't implement
+	// ResultMetricWithExemplar.
+	IncrementResult(metric, "200", "GET", "example.com", "trace-1", "span-1")
+}
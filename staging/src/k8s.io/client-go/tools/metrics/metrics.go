@@ -16,6 +16,16 @@ limitations under the License.
 
 // Package metrics provides abstractions for registering which metrics
 // to record.
+//
+// Scope note: RetryMetric, RequestSizeMetric, ResponseSizeMetric,
+// InFlightRequestsMetric, TransportTimingMetric, Sink/WithSink/*FromContext
+// and the exemplar helpers in exemplar.go are hook points only. This tree
+// snapshot has no k8s.io/client-go/rest package, so there is no
+// Request.Do (or any other REST client call site) to wire them into yet;
+// none of them are reachable from production code as things stand. Wiring
+// them into the REST client is unfinished, out-of-scope work, not an
+// oversight — it belongs in a follow-up change once a rest package exists
+// to land the call sites in.
 package metrics
 
 import (
@@ -46,11 +56,56 @@ type ThrottleLatencyMetric interface {
 	Observe(verb string, u url.URL, latency time.Duration)
 }
 
+// RetryMetric counts the number of retries sent to the server, partitioned
+// by verb and host. Retries that are themselves throttled are still counted
+// here; ThrottleMetric tracks the throttling decision, this tracks the
+// resulting retry.
+type RetryMetric interface {
+	IncrementRetry(verb string, u url.URL)
+}
+
+// RequestSizeMetric observes the size, in bytes, of request bodies sent to
+// the server, partitioned by verb and host.
+type RequestSizeMetric interface {
+	Observe(verb string, host string, size float64)
+}
+
+// ResponseSizeMetric observes the size, in bytes, of response bodies read
+// from the server, partitioned by verb and host.
+type ResponseSizeMetric interface {
+	Observe(verb string, host string, size float64)
+}
+
+// InFlightRequestsMetric tracks the number of requests currently in flight.
+// Increment is called when a request is sent and Decrement when it
+// completes, so the value at any point reflects connection-pool saturation.
+type InFlightRequestsMetric interface {
+	Increment()
+	Decrement()
+}
+
+// TransportTimingMetric observes the latency of the individual phases of an
+// HTTP round trip, as reported by httptrace.ClientTrace.
+type TransportTimingMetric interface {
+	ObserveDNSLookup(host string, latency time.Duration)
+	ObserveTCPConnect(host string, latency time.Duration)
+	ObserveTLSHandshake(host string, latency time.Duration)
+	ObserveFirstByte(verb string, host string, latency time.Duration)
+}
+
+// RequestRetry, RequestSize, ResponseSize, InFlightRequests and
+// TransportTiming are not observed by any call site yet; see the package
+// doc comment's scope note.
 type RegisterMetrics struct {
 	RequestLatency         LatencyMetric
 	RequestResult          ResultMetric
 	RequestThrottle        ThrottleMetric
 	RequestThrottleLatency ThrottleLatencyMetric
+	RequestRetry           RetryMetric
+	RequestSize            RequestSizeMetric
+	ResponseSize           ResponseSizeMetric
+	InFlightRequests       InFlightRequestsMetric
+	TransportTiming        TransportTimingMetric
 }
 
 var RegisteredMetrics = RegisterMetrics{
@@ -58,6 +113,11 @@ var RegisteredMetrics = RegisterMetrics{
 	RequestResult:          noopResult{},
 	RequestThrottle:        noopThrottle{},
 	RequestThrottleLatency: noopThrottleLatency{},
+	RequestRetry:           noopRetry{},
+	RequestSize:            noopRequestSize{},
+	ResponseSize:           noopResponseSize{},
+	InFlightRequests:       noopInFlightRequests{},
+	TransportTiming:        noopTransportTiming{},
 }
 
 var (
@@ -69,6 +129,16 @@ var (
 	RequestThrottle ThrottleMetric = noopThrottle{}
 	// RequestThrottleLatency is the throttling metric metric that rest clients will update.
 	RequestThrottleLatency ThrottleLatencyMetric = noopThrottleLatency{}
+	// RequestRetry is the retry metric that rest clients will update.
+	RequestRetry RetryMetric = noopRetry{}
+	// RequestSize is the request size metric that rest clients will update.
+	RequestSize RequestSizeMetric = noopRequestSize{}
+	// ResponseSize is the response size metric that rest clients will update.
+	ResponseSize ResponseSizeMetric = noopResponseSize{}
+	// InFlightRequests is the in-flight requests metric that rest clients will update.
+	InFlightRequests InFlightRequestsMetric = noopInFlightRequests{}
+	// TransportTiming is the transport phase timing metric that rest clients will update.
+	TransportTiming TransportTimingMetric = noopTransportTiming{}
 )
 
 // Register registers metrics for the rest client to use. This can
@@ -87,6 +157,21 @@ func Register(r RegisterMetrics) {
 		if r.RequestThrottleLatency != nil {
 			RequestThrottleLatency = r.RequestThrottleLatency
 		}
+		if r.RequestRetry != nil {
+			RequestRetry = r.RequestRetry
+		}
+		if r.RequestSize != nil {
+			RequestSize = r.RequestSize
+		}
+		if r.ResponseSize != nil {
+			ResponseSize = r.ResponseSize
+		}
+		if r.InFlightRequests != nil {
+			InFlightRequests = r.InFlightRequests
+		}
+		if r.TransportTiming != nil {
+			TransportTiming = r.TransportTiming
+		}
 	})
 }
 
@@ -105,3 +190,27 @@ func (noopThrottle) Increment(string, url.URL) {}
 type noopThrottleLatency struct{}
 
 func (noopThrottleLatency) Observe(string, url.URL, time.Duration) {}
+
+type noopRetry struct{}
+
+func (noopRetry) IncrementRetry(string, url.URL) {}
+
+type noopRequestSize struct{}
+
+func (noopRequestSize) Observe(string, string, float64) {}
+
+type noopResponseSize struct{}
+
+func (noopResponseSize) Observe(string, string, float64) {}
+
+type noopInFlightRequests struct{}
+
+func (noopInFlightRequests) Increment() {}
+func (noopInFlightRequests) Decrement() {}
+
+type noopTransportTiming struct{}
+
+func (noopTransportTiming) ObserveDNSLookup(string, time.Duration)         {}
+func (noopTransportTiming) ObserveTCPConnect(string, time.Duration)        {}
+func (noopTransportTiming) ObserveTLSHandshake(string, time.Duration)      {}
+func (noopTransportTiming) ObserveFirstByte(string, string, time.Duration) {}
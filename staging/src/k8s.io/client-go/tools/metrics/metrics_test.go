@@ -0,0 +1,84 @@
+/*
+Copyright 2015 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/url"
+	"testing"
+)
+
+type fakeRetryMetric struct{ name string }
+
+func (fakeRetryMetric) IncrementRetry(string, url.URL) {}
+
+type fakeRequestSizeMetric struct{}
+
+func (fakeRequestSizeMetric) Observe(string, string, float64) {}
+
+type fakeInFlightRequestsMetric struct{}
+
+func (fakeInFlightRequestsMetric) Increment() {}
+func (fakeInFlightRequestsMetric) Decrement() {}
+
+// TestRegister exercises Register's nil-fallback-to-previous semantics: a
+// nil field in the RegisterMetrics passed in leaves whatever was previously
+// installed (the noop default, since Register can only run once per
+// process), while a non-nil field overrides it. Register is guarded by a
+// package-level sync.Once, so this must happen in a single call covering
+// both cases rather than across multiple test functions.
+func TestRegister(t *testing.T) {
+	retry := fakeRetryMetric{name: "first"}
+	size := fakeRequestSizeMetric{}
+	inFlight := fakeInFlightRequestsMetric{}
+
+	Register(RegisterMetrics{
+		RequestRetry:     retry,
+		RequestSize:      size,
+		InFlightRequests: inFlight,
+		// ResponseSize and TransportTiming are left nil and must not
+		// override the defaults.
+	})
+
+	if RequestRetry != RetryMetric(retry) {
+		t.Errorf("expected a non-nil RequestRetry to override the default, got %#v", RequestRetry)
+	}
+	if RequestSize != RequestSizeMetric(size) {
+		t.Errorf("expected a non-nil RequestSize to override the default, got %#v", RequestSize)
+	}
+	if InFlightRequests != InFlightRequestsMetric(inFlight) {
+		t.Errorf("expected a non-nil InFlightRequests to override the default, got %#v", InFlightRequests)
+	}
+	if _, ok := ResponseSize.(noopResponseSize); !ok {
+		t.Errorf("expected a nil ResponseSize field to leave the default noop in place, got %#v", ResponseSize)
+	}
+	if _, ok := TransportTiming.(noopTransportTiming); !ok {
+		t.Errorf("expected a nil TransportTiming field to leave the default noop in place, got %#v", TransportTiming)
+	}
+
+	// A second call must be a no-op: Register can only take effect once
+	// per process.
+	secondRetry := fakeRetryMetric{name: "second"}
+	Register(RegisterMetrics{RequestRetry: secondRetry, RequestSize: fakeRequestSizeMetric{}})
+	if RequestRetry != RetryMetric(retry) {
+		t.Errorf("expected the second Register call to be a no-op, got %#v", RequestRetry)
+	}
+
+	// Sanity check that the recorded metric is actually usable.
+	RequestRetry.IncrementRetry("GET", url.URL{})
+	RequestSize.Observe("GET", "example.com", 0)
+	InFlightRequests.Increment()
+}
@@ -0,0 +1,398 @@
+/*
+Copyright 2015 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Sink bundles every metric interface the rest client reports into, so that
+// a single value can be attached to a request context. Any field left nil
+// falls back to the corresponding process-wide metric registered via
+// Register, which keeps callers that never set up a Sink working exactly as
+// before.
+type Sink struct {
+	RequestLatency         LatencyMetric
+	RequestResult          ResultMetric
+	RequestThrottle        ThrottleMetric
+	RequestThrottleLatency ThrottleLatencyMetric
+	RequestRetry           RetryMetric
+	RequestSize            RequestSizeMetric
+	ResponseSize           ResponseSizeMetric
+	InFlightRequests       InFlightRequestsMetric
+	TransportTiming        TransportTimingMetric
+}
+
+type sinkContextKey struct{}
+
+// WithSink returns a copy of ctx carrying sink. The *FromContext helpers
+// below consult it before falling back to the global metrics registered
+// via Register, so code that reports through them can send the metrics of
+// different clients in the same process (for example a controller and a
+// sidecar reconciler) to separate Prometheus registries, and tests can
+// assert on the metrics a particular request produced.
+//
+// No REST client call site reads from a Sink yet; the *FromContext helpers
+// below are not called from Request.Do. See the package doc comment's
+// scope note.
+func WithSink(ctx context.Context, sink Sink) context.Context {
+	return context.WithValue(ctx, sinkContextKey{}, sink)
+}
+
+func sinkFromContext(ctx context.Context) (Sink, bool) {
+	sink, ok := ctx.Value(sinkContextKey{}).(Sink)
+	return sink, ok
+}
+
+// LatencyFromContext returns the LatencyMetric the request should report to:
+// the one attached to ctx via WithSink if present and non-nil, otherwise the
+// process-wide RequestLatency.
+func LatencyFromContext(ctx context.Context) LatencyMetric {
+	if sink, ok := sinkFromContext(ctx); ok && sink.RequestLatency != nil {
+		return sink.RequestLatency
+	}
+	return RequestLatency
+}
+
+// ResultFromContext returns the ResultMetric the request should report to.
+func ResultFromContext(ctx context.Context) ResultMetric {
+	if sink, ok := sinkFromContext(ctx); ok && sink.RequestResult != nil {
+		return sink.RequestResult
+	}
+	return RequestResult
+}
+
+// ThrottleFromContext returns the ThrottleMetric the request should report to.
+func ThrottleFromContext(ctx context.Context) ThrottleMetric {
+	if sink, ok := sinkFromContext(ctx); ok && sink.RequestThrottle != nil {
+		return sink.RequestThrottle
+	}
+	return RequestThrottle
+}
+
+// ThrottleLatencyFromContext returns the ThrottleLatencyMetric the request
+// should report to.
+func ThrottleLatencyFromContext(ctx context.Context) ThrottleLatencyMetric {
+	if sink, ok := sinkFromContext(ctx); ok && sink.RequestThrottleLatency != nil {
+		return sink.RequestThrottleLatency
+	}
+	return RequestThrottleLatency
+}
+
+// RetryFromContext returns the RetryMetric the request should report to.
+func RetryFromContext(ctx context.Context) RetryMetric {
+	if sink, ok := sinkFromContext(ctx); ok && sink.RequestRetry != nil {
+		return sink.RequestRetry
+	}
+	return RequestRetry
+}
+
+// RequestSizeFromContext returns the RequestSizeMetric the request should
+// report to.
+func RequestSizeFromContext(ctx context.Context) RequestSizeMetric {
+	if sink, ok := sinkFromContext(ctx); ok && sink.RequestSize != nil {
+		return sink.RequestSize
+	}
+	return RequestSize
+}
+
+// ResponseSizeFromContext returns the ResponseSizeMetric the request should
+// report to.
+func ResponseSizeFromContext(ctx context.Context) ResponseSizeMetric {
+	if sink, ok := sinkFromContext(ctx); ok && sink.ResponseSize != nil {
+		return sink.ResponseSize
+	}
+	return ResponseSize
+}
+
+// InFlightRequestsFromContext returns the InFlightRequestsMetric the request
+// should report to.
+func InFlightRequestsFromContext(ctx context.Context) InFlightRequestsMetric {
+	if sink, ok := sinkFromContext(ctx); ok && sink.InFlightRequests != nil {
+		return sink.InFlightRequests
+	}
+	return InFlightRequests
+}
+
+// TransportTimingFromContext returns the TransportTimingMetric the request
+// should report to.
+func TransportTimingFromContext(ctx context.Context) TransportTimingMetric {
+	if sink, ok := sinkFromContext(ctx); ok && sink.TransportTiming != nil {
+		return sink.TransportTiming
+	}
+	return TransportTiming
+}
+
+// TestSink provides, through its Sink method, a Sink whose observations are
+// recorded in memory instead of being exported anywhere, so table-driven
+// tests can assert on exactly what a request reported. Use NewTestSink to
+// construct one.
+type TestSink struct {
+	mu sync.Mutex
+
+	latencies          []LatencyObservation
+	results            []ResultObservation
+	throttles          []ThrottleObservation
+	throttleLatencies  []LatencyObservation
+	retries            []ThrottleObservation
+	requestSizes       []SizeObservation
+	responseSizes      []SizeObservation
+	inFlightIncrements int
+	inFlightDecrements int
+	transportTimings   []TransportTimingObservation
+}
+
+// LatencyObservation records a single call to a LatencyMetric or
+// ThrottleLatencyMetric.
+type LatencyObservation struct {
+	Verb    string
+	URL     url.URL
+	Latency time.Duration
+}
+
+// ResultObservation records a single call to a ResultMetric.
+type ResultObservation struct {
+	Code   string
+	Method string
+	Host   string
+}
+
+// ThrottleObservation records a single call to a ThrottleMetric or
+// RetryMetric.
+type ThrottleObservation struct {
+	Verb string
+	URL  url.URL
+}
+
+// SizeObservation records a single call to a RequestSizeMetric or
+// ResponseSizeMetric.
+type SizeObservation struct {
+	Verb string
+	Host string
+	Size float64
+}
+
+// TransportTimingObservation records a single call to a
+// TransportTimingMetric. Phase is one of "dns", "tcp", "tls" or
+// "first-byte"; Verb is only populated for the "first-byte" phase, which is
+// the only one ObserveFirstByte reports per-verb.
+type TransportTimingObservation struct {
+	Phase   string
+	Verb    string
+	Host    string
+	Latency time.Duration
+}
+
+// NewTestSink returns a TestSink backed by in-memory recorders. Pass the
+// result of its Sink method to WithSink, issue requests, and then use the
+// inspection methods below to assert on what was observed.
+func NewTestSink() *TestSink {
+	return &TestSink{}
+}
+
+// Sink returns the Sink to attach to a request context via WithSink.
+func (t *TestSink) Sink() Sink {
+	return Sink{
+		RequestLatency:         (*testLatency)(t),
+		RequestResult:          (*testResult)(t),
+		RequestThrottle:        (*testThrottle)(t),
+		RequestThrottleLatency: (*testThrottleLatency)(t),
+		RequestRetry:           (*testRetry)(t),
+		RequestSize:            (*testRequestSize)(t),
+		ResponseSize:           (*testResponseSize)(t),
+		InFlightRequests:       (*testInFlightRequests)(t),
+		TransportTiming:        (*testTransportTiming)(t),
+	}
+}
+
+// Latencies returns every latency observation recorded so far.
+func (t *TestSink) Latencies() []LatencyObservation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]LatencyObservation(nil), t.latencies...)
+}
+
+// Results returns every result observation recorded so far.
+func (t *TestSink) Results() []ResultObservation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]ResultObservation(nil), t.results...)
+}
+
+// Throttles returns every throttle observation recorded so far.
+func (t *TestSink) Throttles() []ThrottleObservation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]ThrottleObservation(nil), t.throttles...)
+}
+
+// ThrottleLatencies returns every throttle-latency observation recorded so
+// far.
+func (t *TestSink) ThrottleLatencies() []LatencyObservation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]LatencyObservation(nil), t.throttleLatencies...)
+}
+
+// Retries returns every retry observation recorded so far.
+func (t *TestSink) Retries() []ThrottleObservation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]ThrottleObservation(nil), t.retries...)
+}
+
+// RequestSizes returns every request-size observation recorded so far.
+func (t *TestSink) RequestSizes() []SizeObservation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]SizeObservation(nil), t.requestSizes...)
+}
+
+// ResponseSizes returns every response-size observation recorded so far.
+func (t *TestSink) ResponseSizes() []SizeObservation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]SizeObservation(nil), t.responseSizes...)
+}
+
+// InFlightCounts returns the number of Increment and Decrement calls
+// recorded so far.
+func (t *TestSink) InFlightCounts() (increments, decrements int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.inFlightIncrements, t.inFlightDecrements
+}
+
+// TransportTimings returns every transport-timing observation recorded so
+// far.
+func (t *TestSink) TransportTimings() []TransportTimingObservation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TransportTimingObservation(nil), t.transportTimings...)
+}
+
+type testLatency TestSink
+
+func (t *testLatency) Observe(verb string, u url.URL, latency time.Duration) {
+	s := (*TestSink)(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latencies = append(s.latencies, LatencyObservation{Verb: verb, URL: u, Latency: latency})
+}
+
+type testResult TestSink
+
+func (t *testResult) Increment(code, method, host string) {
+	s := (*TestSink)(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = append(s.results, ResultObservation{Code: code, Method: method, Host: host})
+}
+
+type testThrottle TestSink
+
+func (t *testThrottle) Increment(verb string, u url.URL) {
+	s := (*TestSink)(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.throttles = append(s.throttles, ThrottleObservation{Verb: verb, URL: u})
+}
+
+type testThrottleLatency TestSink
+
+func (t *testThrottleLatency) Observe(verb string, u url.URL, latency time.Duration) {
+	s := (*TestSink)(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.throttleLatencies = append(s.throttleLatencies, LatencyObservation{Verb: verb, URL: u, Latency: latency})
+}
+
+type testRetry TestSink
+
+func (t *testRetry) IncrementRetry(verb string, u url.URL) {
+	s := (*TestSink)(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries = append(s.retries, ThrottleObservation{Verb: verb, URL: u})
+}
+
+type testRequestSize TestSink
+
+func (t *testRequestSize) Observe(verb, host string, size float64) {
+	s := (*TestSink)(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requestSizes = append(s.requestSizes, SizeObservation{Verb: verb, Host: host, Size: size})
+}
+
+type testResponseSize TestSink
+
+func (t *testResponseSize) Observe(verb, host string, size float64) {
+	s := (*TestSink)(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responseSizes = append(s.responseSizes, SizeObservation{Verb: verb, Host: host, Size: size})
+}
+
+type testInFlightRequests TestSink
+
+func (t *testInFlightRequests) Increment() {
+	s := (*TestSink)(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlightIncrements++
+}
+
+func (t *testInFlightRequests) Decrement() {
+	s := (*TestSink)(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlightDecrements++
+}
+
+type testTransportTiming TestSink
+
+func (t *testTransportTiming) ObserveDNSLookup(host string, latency time.Duration) {
+	s := (*TestSink)(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transportTimings = append(s.transportTimings, TransportTimingObservation{Phase: "dns", Host: host, Latency: latency})
+}
+
+func (t *testTransportTiming) ObserveTCPConnect(host string, latency time.Duration) {
+	s := (*TestSink)(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transportTimings = append(s.transportTimings, TransportTimingObservation{Phase: "tcp", Host: host, Latency: latency})
+}
+
+func (t *testTransportTiming) ObserveTLSHandshake(host string, latency time.Duration) {
+	s := (*TestSink)(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transportTimings = append(s.transportTimings, TransportTimingObservation{Phase: "tls", Host: host, Latency: latency})
+}
+
+func (t *testTransportTiming) ObserveFirstByte(verb, host string, latency time.Duration) {
+	s := (*TestSink)(t)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transportTimings = append(s.transportTimings, TransportTimingObservation{Phase: "first-byte", Verb: verb, Host: host, Latency: latency})
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2015 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestLatencyFromContextFallsBackToGlobal(t *testing.T) {
+	if got := LatencyFromContext(context.Background()); got != RequestLatency {
+		t.Errorf("expected the process-wide RequestLatency when no sink is attached, got %v", got)
+	}
+}
+
+func TestLatencyFromContextUsesSink(t *testing.T) {
+	sink := NewTestSink()
+	ctx := WithSink(context.Background(), sink.Sink())
+
+	if got := LatencyFromContext(ctx); got != sink.Sink().RequestLatency {
+		t.Errorf("expected the sink's RequestLatency, got %v", got)
+	}
+}
+
+func TestLatencyFromContextIgnoresNilSinkField(t *testing.T) {
+	ctx := WithSink(context.Background(), Sink{})
+
+	if got := LatencyFromContext(ctx); got != RequestLatency {
+		t.Errorf("expected a nil Sink field to fall back to the global, got %v", got)
+	}
+}
+
+func TestTestSinkRecordsAllMetrics(t *testing.T) {
+	sink := NewTestSink()
+	s := sink.Sink()
+	u := url.URL{Path: "/api/v1/pods"}
+
+	s.RequestLatency.Observe("GET", u, time.Second)
+	s.RequestResult.Increment("200", "GET", "example.com")
+	s.RequestThrottle.Increment("GET", u)
+	s.RequestThrottleLatency.Observe("GET", u, time.Millisecond)
+	s.RequestRetry.IncrementRetry("GET", u)
+	s.RequestSize.Observe("GET", "example.com", 128)
+	s.ResponseSize.Observe("GET", "example.com", 256)
+	s.InFlightRequests.Increment()
+	s.InFlightRequests.Increment()
+	s.InFlightRequests.Decrement()
+	s.TransportTiming.ObserveDNSLookup("example.com", time.Millisecond)
+	s.TransportTiming.ObserveTCPConnect("example.com", time.Millisecond)
+	s.TransportTiming.ObserveTLSHandshake("example.com", time.Millisecond)
+	s.TransportTiming.ObserveFirstByte("GET", "example.com", time.Millisecond)
+
+	if got := sink.Latencies(); len(got) != 1 {
+		t.Errorf("expected 1 latency observation, got %d", len(got))
+	}
+	if got := sink.Results(); len(got) != 1 {
+		t.Errorf("expected 1 result observation, got %d", len(got))
+	}
+	if got := sink.Throttles(); len(got) != 1 {
+		t.Errorf("expected 1 throttle observation, got %d", len(got))
+	}
+	if got := sink.ThrottleLatencies(); len(got) != 1 {
+		t.Errorf("expected 1 throttle-latency observation, got %d", len(got))
+	}
+	if got := sink.Retries(); len(got) != 1 {
+		t.Errorf("expected 1 retry observation, got %d", len(got))
+	}
+	if got := sink.RequestSizes(); len(got) != 1 {
+		t.Errorf("expected 1 request-size observation, got %d", len(got))
+	}
+	if got := sink.ResponseSizes(); len(got) != 1 {
+		t.Errorf("expected 1 response-size observation, got %d", len(got))
+	}
+	if inc, dec := sink.InFlightCounts(); inc != 2 || dec != 1 {
+		t.Errorf("expected 2 increments and 1 decrement, got %d/%d", inc, dec)
+	}
+	if got := sink.TransportTimings(); len(got) != 4 {
+		t.Errorf("expected 4 transport-timing observations, got %d", len(got))
+	}
+}